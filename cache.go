@@ -0,0 +1,128 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// cacheNotFoundMarker is stored in place of a real response body to negatively
+// cache an upstream 404/no-match so a repeated lookup for a missing
+// identifier doesn't keep hammering JSTOR/ARTSTOR
+const cacheNotFoundMarker = "\x00not-found"
+
+// Cache fronts the upstream JSTOR/ARTSTOR calls so repeated lookups for the
+// same identifier don't re-hit the upstream within the TTL window
+type Cache interface {
+	// Get returns the cached value for key and whether it was present and unexpired
+	Get(key string) (string, bool)
+	// Set stores value for key, expiring it after ttl
+	Set(key string, value string, ttl time.Duration)
+	// DeletePrefix removes every entry whose key starts with prefix, used to
+	// invalidate all cached lookups for a single identifier
+	DeletePrefix(prefix string)
+}
+
+// lruEntry is one node in the LRU's backing list
+type lruEntry struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+// lruCache is the default in-memory Cache implementation
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+// newLRUCache creates an in-memory cache that evicts the least recently used
+// entry once capacity is exceeded
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{capacity: capacity, items: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *lruCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+func (c *lruCache) Set(key string, value string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = time.Now().Add(ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	el := c.order.PushFront(&lruEntry{key: key, value: value, expires: time.Now().Add(ttl)})
+	c.items[key] = el
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
+
+func (c *lruCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(el)
+			delete(c.items, key)
+		}
+	}
+}
+
+// redisCache is the Redis-backed Cache implementation, selected with -cache=redis
+type redisCache struct {
+	client *redis.Client
+}
+
+// newRedisCache creates a Cache backed by the Redis instance at addr
+func newRedisCache(addr string) *redisCache {
+	return &redisCache{client: redis.NewClient(&redis.Options{Addr: addr})}
+}
+
+func (c *redisCache) Get(key string) (string, bool) {
+	val, err := c.client.Get(context.Background(), key).Result()
+	if err != nil {
+		return "", false
+	}
+	return val, true
+}
+
+func (c *redisCache) Set(key string, value string, ttl time.Duration) {
+	c.client.Set(context.Background(), key, value, ttl)
+}
+
+func (c *redisCache) DeletePrefix(prefix string) {
+	ctx := context.Background()
+	iter := c.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		c.client.Del(ctx, iter.Val())
+	}
+}
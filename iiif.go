@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// manifestHandler serves a IIIF Presentation API v3 manifest for the resolved
+// asset so downstream viewers (Mirador, UV) can consume JSTOR-hosted images
+// through Aries without re-implementing the JSTOR admin API lookup
+func manifestHandler(c *gin.Context) {
+	passedID := c.Param("id")
+	ctx := c.Request.Context()
+	iiifURL, found, err := lookupIIIFURL(ctx, passedID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "%s manifest lookup failed: %s", passedID, err.Error())
+		return
+	}
+	if !found || iiifURL == "" {
+		c.String(http.StatusNotFound, "%s not found", passedID)
+		return
+	}
+
+	info, err := fetchIIIFImageInfo(iiifURL)
+	if err != nil {
+		log.Warn().Str("request_id", requestIDFromContext(ctx)).Str("id", passedID).Err(err).Msg("Unable to fetch IIIF image info")
+	}
+
+	manifest := buildIIIFManifest(serviceBaseURL(c), passedID, iiifURL, info)
+	c.Data(http.StatusOK, "application/ld+json", manifest)
+}
+
+// lookupIIIFURL locates the JSTOR representation for id and returns its
+// IIIF Image API base URL, if any
+func lookupIIIFURL(ctx context.Context, id string) (string, bool, error) {
+	var filterTerms []string
+	idF := map[string]string{"type": "numeric", "comparison": "eq",
+		"value": id, "field": "id", "fieldName": "SSID"}
+	ifnF := map[string]string{"type": "string", "field": "filename", "fieldName": "Filename",
+		"value": fmt.Sprintf("%s*", id)}
+	filterTerms = append(filterTerms, mapToEncodedString(idF))
+	filterTerms = append(filterTerms, mapToEncodedString(ifnF))
+
+	var lastErr error
+	for _, filter := range filterTerms {
+		qp := "with_meta=false&start=0&limit=1&sort=id&dir=DESC&filter="
+		URL := fmt.Sprintf("%s/projects/%s/assets?%s[%s]", jstorURL, jstorProject, qp, filter)
+		respStr, err := getJstorResponse(ctx, id, URL, true, true)
+		if err != nil {
+			if errors.Is(err, errCachedNotFound) {
+				// negatively-cached 404: treat like a live miss, not a failure
+				continue
+			}
+			lastErr = err
+			continue
+		}
+		var resp jstorResp
+		if marshallErr := json.Unmarshal([]byte(respStr), &resp); marshallErr != nil {
+			lastErr = marshallErr
+			continue
+		}
+		if resp.Total != 1 {
+			continue
+		}
+		hit := resp.Assets[0]
+		repURL := fmt.Sprintf("%s/assets/%d/representation/details?_dc=%s", jstorURL, hit.ID, hit.RepresentationID)
+		repRespStr, err := getJstorResponse(ctx, id, repURL, true, true)
+		if err != nil {
+			if errors.Is(err, errCachedNotFound) {
+				continue
+			}
+			lastErr = err
+			continue
+		}
+		var repInfo jstorResource
+		if marshallErr := json.Unmarshal([]byte(repRespStr), &repInfo); marshallErr != nil {
+			lastErr = marshallErr
+			continue
+		}
+		return repInfo.IIIF, true, nil
+	}
+	return "", false, lastErr
+}
+
+// fetchIIIFImageInfo requests the IIIF Image API info.json for baseURL to
+// learn the image dimensions used in the manifest canvas
+func fetchIIIFImageInfo(baseURL string) (*iiifImageInfo, error) {
+	client := http.Client{Timeout: requestTimeout}
+	resp, err := client.Get(fmt.Sprintf("%s/info.json", baseURL))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	var info iiifImageInfo
+	if err := json.Unmarshal(bodyBytes, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// buildIIIFManifest translates a JSTOR IIIF Image API base URL into a minimal
+// single-canvas IIIF Presentation API v3 manifest. baseURL is this Aries
+// service's own base URL, used for the manifest/canvas/annotation ids so a
+// viewer can dereference them; iiifBaseURL (JSTOR's image service) is used
+// only for the image body/service ids. info may be nil if the image
+// dimensions could not be determined
+func buildIIIFManifest(baseURL string, id string, iiifBaseURL string, info *iiifImageInfo) []byte {
+	selfURL := fmt.Sprintf("%s/api/aries/%s/manifest", baseURL, id)
+	canvasID := fmt.Sprintf("%s/canvas/1", selfURL)
+	width, height := 0, 0
+	if info != nil {
+		width, height = info.Width, info.Height
+	}
+
+	manifest := iiifManifest{
+		Context: "http://iiif.io/api/presentation/3/context.json",
+		ID:      selfURL,
+		Type:    "Manifest",
+		Label:   map[string][]string{"none": {id}},
+		Items: []iiifCanvas{
+			{
+				ID:     canvasID,
+				Type:   "Canvas",
+				Height: height,
+				Width:  width,
+				Items: []iiifAnnotationPage{
+					{
+						ID:   fmt.Sprintf("%s/page", canvasID),
+						Type: "AnnotationPage",
+						Items: []iiifAnnotation{
+							{
+								ID:         fmt.Sprintf("%s/annotation", canvasID),
+								Type:       "Annotation",
+								Motivation: "painting",
+								Target:     canvasID,
+								Body: iiifImgBody{
+									ID:     fmt.Sprintf("%s/full/max/0/default.jpg", iiifBaseURL),
+									Type:   "Image",
+									Format: "image/jpeg",
+									Height: height,
+									Width:  width,
+									Service: []iiifImgService{
+										{ID: iiifBaseURL, Type: "ImageService3", Profile: "level2"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	out, _ := json.Marshal(manifest)
+	return out
+}
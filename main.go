@@ -2,21 +2,23 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/net/publicsuffix"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
 )
 
 // Version of the service
@@ -28,8 +30,19 @@ var jstorURL string
 var jstorProject string
 var jstorEmail string
 var jstorPass string
-var jstorCookies []*http.Cookie
-var artstorCookies []*http.Cookie
+var jstorSessionMgr *SessionManager
+var artstorSessionMgr *SessionManager
+var workerCount int
+var requestTimeout time.Duration
+var jstorSessionTTL time.Duration
+var respCache Cache
+var cacheTTL time.Duration
+var cacheNegativeTTL time.Duration
+
+// errCachedNotFound is returned for a negatively cached lookup (an upstream 404
+// or a search with no match); callers check for it with errors.Is and treat it
+// as a miss rather than a failure
+var errCachedNotFound = errors.New("not found (cached)")
 
 // favHandler is a dummy handler to silence browser API requests that look for /favicon.ico
 func favHandler(c *gin.Context) {
@@ -46,9 +59,10 @@ func healthCheckHandler(c *gin.Context) {
 	hcMap["AriesJSTOR"] = "true"
 	// ping the api with a minimal request to see if it is alive
 	url := fmt.Sprintf("%s/projects/%s/assets?with_meta=false&start=0&limit=0", jstorURL, jstorProject)
-	_, err := getJstorResponse(url, true)
+	// bypass the cache so a stale cached ping can't mask JSTOR actually being down
+	_, err := getJstorResponse(c.Request.Context(), "healthcheck", url, true, false)
 	if err != nil {
-		log.Printf("HealthCheck JSTOR ping failed: %s", err.Error())
+		log.Error().Str("request_id", requestIDFromContext(c.Request.Context())).Err(err).Msg("HealthCheck JSTOR ping failed")
 		hcMap["JSTOR"] = "false"
 	} else {
 		hcMap["JSTOR"] = "true"
@@ -61,10 +75,96 @@ func ariesPing(c *gin.Context) {
 	c.String(http.StatusOK, "JSTOR Aries API")
 }
 
+// cacheDeleteHandler invalidates every cached JSTOR/ARTSTOR lookup for one identifier
+func cacheDeleteHandler(c *gin.Context) {
+	id := c.Param("id")
+	respCache.DeletePrefix(id + "|")
+	c.String(http.StatusOK, "%s cache invalidated", id)
+}
+
 // ariesLookup will query APTrust for information on the supplied identifer
 func ariesLookup(c *gin.Context) {
-	// create filters to search by ID and filename. Prefer ID hit.
 	passedID := c.Param("id")
+	out, found, err := lookupAries(c.Request.Context(), passedID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "%s lookup failed: %s", passedID, err.Error())
+		return
+	}
+	if !found {
+		c.String(http.StatusNotFound, "%s not found", passedID)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// batchResult is the per-identifier outcome returned by the batch endpoint
+type batchResult struct {
+	Status string `json:"status"`
+	Data   *aries `json:"data,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// batchLookup fans out identifier lookups across a bounded pool of workers so a
+// caller reconciling large sets of Tracksys/APTrust records can resolve them
+// in a single round trip instead of one request per identifier
+func batchLookup(c *gin.Context) {
+	var ids []string
+	if err := c.BindJSON(&ids); err != nil {
+		c.String(http.StatusBadRequest, "invalid request: %s", err.Error())
+		return
+	}
+	if len(ids) == 0 {
+		c.String(http.StatusBadRequest, "no identifiers supplied")
+		return
+	}
+	ctx := c.Request.Context()
+
+	type job struct {
+		index int
+		id    string
+	}
+	jobs := make(chan job)
+	results := make([]batchResult, len(ids))
+
+	workers := workerCount
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				out, found, err := lookupAries(ctx, j.id)
+				if err != nil {
+					results[j.index] = batchResult{Status: "error", Error: err.Error()}
+				} else if !found {
+					results[j.index] = batchResult{Status: "not_found"}
+				} else {
+					results[j.index] = batchResult{Status: "found", Data: &out}
+				}
+			}
+		}()
+	}
+	for idx, id := range ids {
+		jobs <- job{index: idx, id: id}
+	}
+	close(jobs)
+	wg.Wait()
+
+	out := make(map[string]batchResult, len(ids))
+	for idx, id := range ids {
+		out[id] = results[idx]
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// lookupAries queries JSTOR/ARTSTOR for the supplied identifier. The returned
+// bool indicates whether a matching asset was found
+func lookupAries(ctx context.Context, passedID string) (aries, bool, error) {
+	reqID := requestIDFromContext(ctx)
+	// create filters to search by ID and filename. Prefer ID hit.
 	var filterTerms []string
 	idF := map[string]string{"type": "numeric", "comparison": "eq",
 		"value": passedID, "field": "id", "fieldName": "SSID"}
@@ -74,21 +174,27 @@ func ariesLookup(c *gin.Context) {
 	filterTerms = append(filterTerms, mapToEncodedString(ifnF))
 
 	var out aries
+	var lastErr error
 	hits := 0
 	for _, filter := range filterTerms {
 		qp := "with_meta=false&start=0&limit=1&sort=id&dir=DESC&filter="
 		URL := fmt.Sprintf("%s/projects/%s/assets?%s[%s]", jstorURL, jstorProject, qp, filter)
-		respStr, err := getJstorResponse(URL, true)
+		respStr, err := getJstorResponse(ctx, passedID, URL, true, true)
 		if err != nil {
+			if errors.Is(err, errCachedNotFound) {
+				// negatively-cached 404: treat like a live miss, not a failure
+				continue
+			}
 			unescaped, _ := url.QueryUnescape(filter)
-			log.Printf("Query filter %s Failed: %s", unescaped, err.Error())
+			log.Error().Str("request_id", reqID).Str("filter", unescaped).Err(err).Msg("Query filter failed")
+			lastErr = err
 			continue
 		}
-		log.Printf("Parsing JSTOR response for %s", passedID)
 		var resp jstorResp
 		marshallErr := json.Unmarshal([]byte(respStr), &resp)
 		if marshallErr != nil {
-			log.Printf("Unable to parse response: %s", marshallErr.Error())
+			log.Error().Str("request_id", reqID).Err(marshallErr).Msg("Unable to parse JSTOR response")
+			lastErr = marshallErr
 			continue
 		}
 		if resp.Total == 0 {
@@ -96,7 +202,7 @@ func ariesLookup(c *gin.Context) {
 		}
 		if resp.Total > 1 {
 			unescaped, _ := url.QueryUnescape(filter)
-			log.Printf("Query filter %s returned more than one hit", unescaped)
+			log.Warn().Str("request_id", reqID).Str("filter", unescaped).Msg("Query filter returned more than one hit")
 			continue
 		}
 		hits++
@@ -104,7 +210,7 @@ func ariesLookup(c *gin.Context) {
 		out.Identifiers = append(out.Identifiers, strconv.Itoa(hit.ID))
 		out.Identifiers = append(out.Identifiers, hit.Filename)
 		repURL := fmt.Sprintf("%s/assets/%d/representation/details?_dc=%s", jstorURL, hit.ID, hit.RepresentationID)
-		repRespStr, err := getJstorResponse(repURL, true)
+		repRespStr, err := getJstorResponse(ctx, passedID, repURL, true, true)
 		if err == nil {
 			var repInfo jstorResource
 			marshallErr = json.Unmarshal([]byte(repRespStr), &repInfo)
@@ -117,8 +223,7 @@ func ariesLookup(c *gin.Context) {
 
 		// look for "status": "Published" in response to see if the item is public
 		if strings.Contains(respStr, "\"status\": \"Published\"") {
-			log.Printf("%s is published, looking for public URL", passedID)
-			pubID := getArtstorPublicID(strconv.Itoa(hit.ID), true)
+			pubID := getArtstorPublicID(ctx, strconv.Itoa(hit.ID), passedID, true)
 			if pubID != "" {
 				out.AccessURL = append(out.AccessURL, fmt.Sprintf("%s/#/asset/%s", publicURL, pubID))
 			}
@@ -128,10 +233,15 @@ func ariesLookup(c *gin.Context) {
 		break
 	}
 	if hits == 0 {
-		c.String(http.StatusNotFound, "%s not found", passedID)
-	} else {
-		c.JSON(http.StatusOK, out)
+		if lastErr != nil {
+			ariesLookupTotal.WithLabelValues("error").Inc()
+			return out, false, lastErr
+		}
+		ariesLookupTotal.WithLabelValues("miss").Inc()
+		return out, false, nil
 	}
+	ariesLookupTotal.WithLabelValues("hit").Inc()
+	return out, true, nil
 }
 
 func mapToEncodedString(val map[string]string) string {
@@ -141,21 +251,35 @@ func mapToEncodedString(val map[string]string) string {
 	return encoded[2:len(encoded)]
 }
 
-// getJstorResponse is a helper used to call a JSON endpoint and return the resoponse as a string
-func getJstorResponse(tgtURL string, retry bool) (string, error) {
-	log.Printf("Get response for: %s", tgtURL)
+// getJstorResponse is a helper used to call a JSON endpoint and return the resoponse as a string.
+// Responses are cached per identifier so repeated lookups for the same id don't
+// keep re-hitting JSTOR within the cache TTL. Pass useCache=false to always hit
+// JSTOR live, e.g. for a liveness check that must not report stale results
+func getJstorResponse(ctx context.Context, id string, tgtURL string, retry bool, useCache bool) (string, error) {
+	reqID := requestIDFromContext(ctx)
+	cacheKey := fmt.Sprintf("%s|GET|%s", id, tgtURL)
+	if useCache {
+		if cached, ok := respCache.Get(cacheKey); ok {
+			if cached == cacheNotFoundMarker {
+				return "", errCachedNotFound
+			}
+			return cached, nil
+		}
+	}
+
+	start := time.Now()
 	apiReq, _ := http.NewRequest("GET", tgtURL, nil)
-	for _, cookie := range jstorCookies {
+	for _, cookie := range jstorSessionMgr.Cookies(apiReq.URL) {
 		apiReq.AddCookie(cookie)
 	}
-	timeout := time.Duration(10 * time.Second)
 	client := http.Client{
-		Timeout: timeout,
+		Timeout: requestTimeout,
 	}
 
 	resp, err := client.Do(apiReq)
 	if err != nil {
-		log.Printf("Unable to GET %s: %s", tgtURL, err.Error())
+		log.Error().Str("request_id", reqID).Str("url", tgtURL).Err(err).Msg("Unable to GET JSTOR endpoint")
+		jstorRequestDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
 		return "", err
 	}
 	defer resp.Body.Close()
@@ -163,125 +287,150 @@ func getJstorResponse(tgtURL string, retry bool) (string, error) {
 	// Forbidden/unauthorized... maybe cookie expired. RE-auth and try again
 	if resp.StatusCode == 403 || resp.StatusCode == 401 {
 		if retry {
-			lerr := jstorLogin()
+			lerr := jstorSessionMgr.ReAuth()
 			if lerr != nil {
-				log.Printf("Unable to GET %s: %s", tgtURL, lerr.Error())
+				log.Error().Str("request_id", reqID).Str("url", tgtURL).Err(lerr).Msg("Unable to re-auth to JSTOR")
+				jstorRequestDuration.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
 				return "", lerr
 			}
-			return getJstorResponse(tgtURL, false)
+			return getJstorResponse(ctx, id, tgtURL, false, useCache)
 		}
-		log.Printf("Unable to GET %s: %s", tgtURL, err.Error())
 	}
 
 	bodyBytes, _ := ioutil.ReadAll(resp.Body)
 	respString := string(bodyBytes)
+	jstorRequestDuration.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
 	if resp.StatusCode != 200 {
+		if useCache && resp.StatusCode == http.StatusNotFound {
+			respCache.Set(cacheKey, cacheNotFoundMarker, cacheNegativeTTL)
+		}
 		return "", errors.New(respString)
 	}
+	if useCache {
+		if isJstorNoMatch(respString) {
+			// JSTOR reports a search "no match" as HTTP 200 with total:0, not a
+			// 404 - negatively cache it like one instead of caching it for the
+			// full positive cacheTTL
+			respCache.Set(cacheKey, cacheNotFoundMarker, cacheNegativeTTL)
+		} else {
+			respCache.Set(cacheKey, respString, cacheTTL)
+		}
+	}
 	return respString, nil
 }
 
+// isJstorNoMatch reports whether respString is a JSTOR asset-search response
+// with total:0
+func isJstorNoMatch(respString string) bool {
+	var probe jstorTotalProbe
+	if err := json.Unmarshal([]byte(respString), &probe); err != nil {
+		return false
+	}
+	return probe.Total != nil && *probe.Total == 0
+}
+
 // getArtstorPublicID will query the artstorPublic API for the artstorid of a published
-// jstorForum identifier. If credentials are rejected, it will retry once
-func getArtstorPublicID(id string, retry bool) string {
-	timeout := time.Duration(10 * time.Second)
+// jstorForum identifier, searching by the numeric ssid. cacheID keys the cache
+// entry instead - it's the identifier the caller was originally asked to look
+// up (which may be a filename, not the numeric ssid), so it matches the prefix
+// DELETE /api/cache/:id invalidates. If credentials are rejected, it will retry once
+func getArtstorPublicID(ctx context.Context, ssid string, cacheID string, retry bool) string {
+	reqID := requestIDFromContext(ctx)
+	cacheKey := fmt.Sprintf("%s|artstor-search", cacheID)
+	if cached, ok := respCache.Get(cacheKey); ok {
+		if cached == cacheNotFoundMarker {
+			return ""
+		}
+		return cached
+	}
+
+	start := time.Now()
 	client := http.Client{
-		Timeout: timeout,
+		Timeout: requestTimeout,
 	}
-	jsonStr := fmt.Sprintf(`{"limit":1,"start":0,"content_types":["art"],"query":"ssid:%s"}`, id)
+	jsonStr := fmt.Sprintf(`{"limit":1,"start":0,"content_types":["art"],"query":"ssid:%s"}`, ssid)
 	URL := fmt.Sprintf("%s/api/search/v1.0/search", publicURL)
-	log.Printf("Get Artstor public ID from: %s with params %s", URL, jsonStr)
 	apiReq, _ := http.NewRequest("POST", URL, bytes.NewBuffer([]byte(jsonStr)))
 	apiReq.Header.Set("Content-Type", "application/json")
 	apiReq.Header.Set("authority", "library.artstor.org")
-	for _, cookie := range artstorCookies {
+	for _, cookie := range artstorSessionMgr.Cookies(apiReq.URL) {
 		apiReq.AddCookie(cookie)
 	}
 	rawResp, err := client.Do(apiReq)
 	if err != nil {
-		log.Printf("Artstor request failed: %s", err.Error())
+		log.Error().Str("request_id", reqID).Err(err).Msg("Artstor request failed")
+		artstorRequestDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
 		return ""
 	}
 
 	defer rawResp.Body.Close()
 	bodyBytes, _ := ioutil.ReadAll(rawResp.Body)
 	respString := string(bodyBytes)
+	artstorRequestDuration.WithLabelValues(strconv.Itoa(rawResp.StatusCode)).Observe(time.Since(start).Seconds())
 
 	if rawResp.StatusCode == 401 || rawResp.StatusCode == 403 {
 		// auth failure; re-auth and try once more
 		if retry {
-			log.Printf("Auth failure for artstor request. Renew session and try again")
-			lerr := artstorSession()
+			log.Warn().Str("request_id", reqID).Msg("Auth failure for artstor request. Renew session and try again")
+			lerr := artstorSessionMgr.ReAuth()
 			if lerr != nil {
-				log.Printf("Unable to query artstor: %s", lerr.Error())
+				log.Error().Str("request_id", reqID).Err(lerr).Msg("Unable to query artstor")
 				return ""
 			}
-			return getArtstorPublicID(id, false)
+			return getArtstorPublicID(ctx, ssid, cacheID, false)
 		}
-		log.Printf("Artstor request failed: %d:%s", rawResp.StatusCode, respString)
+		log.Error().Str("request_id", reqID).Int("status", rawResp.StatusCode).Str("body", respString).Msg("Artstor request failed")
 		return ""
 	} else if rawResp.StatusCode != 200 {
-		log.Printf("Artstor request failed: %d:%s", rawResp.StatusCode, respString)
+		log.Error().Str("request_id", reqID).Int("status", rawResp.StatusCode).Str("body", respString).Msg("Artstor request failed")
 		return ""
 	}
 	var resp artstorResp
 	marshallErr := json.Unmarshal([]byte(respString), &resp)
 	if marshallErr != nil {
-		log.Printf("Unable to parse Artstor response:%s", marshallErr)
+		log.Error().Str("request_id", reqID).Err(marshallErr).Msg("Unable to parse Artstor response")
 		return ""
 	}
 
 	if resp.Total == 1 {
 		asID := resp.Results[0].ArtstorID
-		log.Printf("JSTOR ID %s = ArtSTOR ID %s", id, asID)
+		respCache.Set(cacheKey, asID, cacheTTL)
 		return asID
 	}
-	log.Printf("No matches from Artstor for %s", id)
+	respCache.Set(cacheKey, cacheNotFoundMarker, cacheNegativeTTL)
 	return ""
 }
 
-// artstorSession will request a new ARTSROR session and save the cookies
-func artstorSession() error {
-	log.Printf("Get ARTSTOR session...")
-	cookieJar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
-	timeout := time.Duration(10 * time.Second)
+// artstorLogin will request a new ARTSTOR session, landing its cookies in jar
+func artstorLogin(jar *cookiejar.Jar) error {
 	client := http.Client{
-		Timeout: timeout,
-		Jar:     cookieJar,
+		Timeout: requestTimeout,
+		Jar:     jar,
 	}
 	reqURL := fmt.Sprintf("%s//api/secure/userinfo", publicURL)
-	loginResp, err := client.Get(reqURL)
-	if err != nil {
+	if _, err := client.Get(reqURL); err != nil {
 		return err
 	}
 
-	artstorCookies = loginResp.Cookies()
-	log.Printf("ARTSTOR session started")
+	log.Info().Msg("ARTSTOR session started")
 	return nil
 }
 
-func jstorLogin() error {
-	log.Printf("Logging into JSTOR...")
-	// add a cookie jar to the login POST to retrieve login cookies
-	cookieJar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
-	timeout := time.Duration(10 * time.Second)
+// jstorLogin will authenticate against JSTOR, landing its session cookies in jar
+func jstorLogin(jar *cookiejar.Jar) error {
 	client := http.Client{
-		Timeout: timeout,
-		Jar:     cookieJar,
+		Timeout: requestTimeout,
+		Jar:     jar,
 	}
 	values := url.Values{}
 	values.Set("email", jstorEmail)
 	values.Add("password", jstorPass)
 	reqURL := fmt.Sprintf("%s/account", jstorURL)
-	loginResp, err := client.PostForm(reqURL, values)
-	if err != nil {
+	if _, err := client.PostForm(reqURL, values); err != nil {
 		return err
 	}
 
-	// copy all of the cookies in the jar for future use
-	jstorCookies = loginResp.Cookies()
-
-	log.Printf("JSTOR Login successful")
+	log.Info().Msg("JSTOR login successful")
 	return nil
 }
 
@@ -289,10 +438,11 @@ func jstorLogin() error {
  * MAIN
  */
 func main() {
-	log.Printf("===> Aries JSTOR service staring up <===")
+	setupLogger()
+	log.Info().Msg("===> Aries JSTOR service staring up <===")
 
 	// Get config params
-	log.Printf("Read configuration...")
+	log.Info().Msg("Read configuration...")
 	var port int
 	flag.IntVar(&port, "port", 8080, "Aries JSTOR port (default 8080)")
 	flag.StringVar(&jstorURL, "url", "https://forum.jstor.org", "JSTOR base URL")
@@ -300,34 +450,75 @@ func main() {
 	flag.StringVar(&jstorProject, "project", "", "Target JSTOR project")
 	flag.StringVar(&jstorEmail, "email", "", "JSTOR authorized user email")
 	flag.StringVar(&jstorPass, "pass", "", "JSTOR authorized user passsword")
+	flag.IntVar(&workerCount, "workers", 8, "Number of concurrent workers for batch lookups")
+	var timeoutSec int
+	flag.IntVar(&timeoutSec, "timeout", 10, "Timeout in seconds for upstream JSTOR/ARTSTOR requests")
+	flag.DurationVar(&jstorSessionTTL, "jstor-session-ttl", 30*time.Minute, "Idle interval before pre-emptively renewing the JSTOR/ARTSTOR sessions")
+	var cacheKind string
+	flag.StringVar(&cacheKind, "cache", "memory", "Response cache implementation: memory or redis")
+	var redisAddr string
+	flag.StringVar(&redisAddr, "redis-addr", "localhost:6379", "Redis address, used when -cache=redis")
+	var cacheSize int
+	flag.IntVar(&cacheSize, "cache-size", 1000, "Max entries held by the in-memory cache")
+	flag.DurationVar(&cacheTTL, "cache-ttl", 5*time.Minute, "TTL for cached JSTOR/ARTSTOR responses")
+	flag.DurationVar(&cacheNegativeTTL, "cache-negative-ttl", 30*time.Second, "TTL for negatively cached (not found) lookups")
+	var adminUser string
+	flag.StringVar(&adminUser, "admin-user", "admin", "Username for admin routes (cache invalidation)")
+	var adminPass string
+	flag.StringVar(&adminPass, "admin-pass", "", "Password for admin routes (cache invalidation)")
+	flag.StringVar(&ariesBaseURL, "baseurl", "", "This service's own externally-reachable base URL, used for IIIF manifest ids (derived from the request host if not set)")
 	flag.Parse()
+	requestTimeout = time.Duration(timeoutSec) * time.Second
 
-	// use info above to establish a jstor and artstor login session
-	logErr := jstorLogin()
-	if logErr != nil {
-		log.Fatalf("Unable to login to jstor: %s", logErr.Error())
+	if cacheKind == "redis" {
+		log.Info().Str("addr", redisAddr).Msg("Using redis response cache")
+		respCache = newRedisCache(redisAddr)
+	} else {
+		log.Info().Int("size", cacheSize).Msg("Using in-memory response cache")
+		respCache = newLRUCache(cacheSize)
+	}
+
+	// use info above to establish a jstor and artstor login session, then keep
+	// both warm with a background renewal loop
+	jstorSessionMgr = newSessionManager("jstor", jstorSessionTTL, jstorLogin)
+	artstorSessionMgr = newSessionManager("artstor", jstorSessionTTL, artstorLogin)
+	if logErr := jstorSessionMgr.Login(); logErr != nil {
+		log.Fatal().Err(logErr).Msg("Unable to login to jstor")
 		return
 	}
-	logErr = artstorSession()
-	if logErr != nil {
-		log.Fatalf("Unable to login to artstor: %s", logErr.Error())
+	if logErr := artstorSessionMgr.Login(); logErr != nil {
+		log.Fatal().Err(logErr).Msg("Unable to login to artstor")
 		return
 	}
+	jstorSessionMgr.StartRefreshLoop()
+	artstorSessionMgr.StartRefreshLoop()
 
-	log.Printf("Setup routes...")
+	log.Info().Msg("Setup routes...")
 	gin.SetMode(gin.ReleaseMode)
 	gin.DisableConsoleColor()
-	router := gin.Default()
+	// gin.New() (not gin.Default()) so gin's built-in text access logger
+	// never runs - requestIDMiddleware is the sole access logger, and it
+	// emits structured JSON lines
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(requestIDMiddleware)
 	router.GET("/favicon.ico", favHandler)
 	router.GET("/version", versionHandler)
 	router.GET("/healthcheck", healthCheckHandler)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	api := router.Group("/api")
 	{
 		api.GET("/aries", ariesPing)
 		api.GET("/aries/:id", ariesLookup)
+		api.GET("/aries/:id/manifest", manifestHandler)
+		api.POST("/aries/batch", batchLookup)
+	}
+	admin := router.Group("/api/cache", gin.BasicAuth(gin.Accounts{adminUser: adminPass}))
+	{
+		admin.DELETE("/:id", cacheDeleteHandler)
 	}
 
 	portStr := fmt.Sprintf(":%d", port)
-	log.Printf("Start Aries JSTOR v%s on port %s", version, portStr)
-	log.Fatal(router.Run(portStr))
+	log.Info().Str("version", version).Str("port", portStr).Msg("Start Aries JSTOR")
+	log.Fatal().Err(router.Run(portStr)).Msg("Server exited")
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ariesLookupTotal counts aries lookups by outcome: hit, miss, or error
+var ariesLookupTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aries_lookup_total",
+		Help: "Count of aries lookups by result",
+	},
+	[]string{"result"},
+)
+
+// jstorRequestDuration tracks latency of upstream JSTOR requests, labeled by
+// the response status (or "error" for a failed round trip)
+var jstorRequestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name: "jstor_request_duration_seconds",
+		Help: "Duration of upstream JSTOR requests",
+	},
+	[]string{"status"},
+)
+
+// artstorRequestDuration tracks latency of upstream ARTSTOR requests, labeled
+// by the response status (or "error" for a failed round trip)
+var artstorRequestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name: "artstor_request_duration_seconds",
+		Help: "Duration of upstream ARTSTOR requests",
+	},
+	[]string{"status"},
+)
+
+// jstorSessionReauthTotal counts session re-authentications, labeled by upstream
+var jstorSessionReauthTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "jstor_session_reauth_total",
+		Help: "Count of JSTOR/ARTSTOR session re-authentications",
+	},
+	[]string{"upstream"},
+)
+
+func init() {
+	prometheus.MustRegister(ariesLookupTotal, jstorRequestDuration, artstorRequestDuration, jstorSessionReauthTotal)
+}
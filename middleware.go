@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// ctxKey namespaces values stored on a request context so they don't collide
+// with keys set by other packages
+type ctxKey string
+
+const requestIDKey ctxKey = "request_id"
+
+// ariesBaseURL is this service's own externally-reachable base URL, used to
+// build resolvable ids (e.g. in IIIF manifests). Set via -baseurl; when left
+// unset it is derived per-request from the incoming Host header
+var ariesBaseURL string
+
+// serviceBaseURL returns this Aries service's own base URL for the request,
+// preferring the configured -baseurl flag and falling back to the request's
+// scheme/host so generated ids (e.g. a IIIF manifest id) are dereferenceable
+func serviceBaseURL(c *gin.Context) string {
+	if ariesBaseURL != "" {
+		return ariesBaseURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+}
+
+// setupLogger configures the global zerolog logger to emit structured JSON lines
+func setupLogger() {
+	log.Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+}
+
+// requestIDMiddleware assigns a request id (reusing X-Request-ID if the caller
+// supplied one), threads it through the request context so upstream calls and
+// log lines can be correlated back to one inbound request, and logs a
+// structured summary once the handler completes
+func requestIDMiddleware(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-ID")
+	if reqID == "" {
+		reqID = newRequestID()
+	}
+	c.Writer.Header().Set("X-Request-ID", reqID)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey, reqID))
+
+	start := time.Now()
+	c.Next()
+	log.Info().
+		Str("request_id", reqID).
+		Str("method", c.Request.Method).
+		Str("path", c.Request.URL.Path).
+		Int("status", c.Writer.Status()).
+		Dur("latency", time.Since(start)).
+		Msg("request handled")
+}
+
+// requestIDFromContext returns the request id stashed by requestIDMiddleware,
+// or "" if ctx carries none (for example a background session refresh)
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// newRequestID generates a short random id for requests lacking X-Request-ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
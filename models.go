@@ -17,6 +17,14 @@ type jstorResp struct {
 	Total  int          `json:"total,omitempty"`
 	Assets []jstorAsset `json:"assets,omitempty"`
 }
+
+// jstorTotalProbe detects a JSTOR asset-search "no match" response - JSTOR
+// reports this as HTTP 200 with a body of {"total":0,...}, not a 404. Total
+// is a pointer so a response with no "total" key (e.g. representation/details)
+// is left alone rather than misread as a zero total
+type jstorTotalProbe struct {
+	Total *int `json:"total"`
+}
 type jstorAsset struct {
 	ID               int    `json:"id,omitempty"`
 	Filename         string `json:"filename,omitempty"`
@@ -36,3 +44,56 @@ type artstorResult struct {
 	ID        string `json:"id,omitempty"`
 	ArtstorID string `json:"artstorid,omitempty"`
 }
+
+// iiifImageInfo is the subset of an IIIF Image API info.json response needed
+// to describe the image in a Presentation manifest
+type iiifImageInfo struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// iiifManifest is a minimal IIIF Presentation API v3 manifest with a single canvas
+type iiifManifest struct {
+	Context string              `json:"@context"`
+	ID      string              `json:"id"`
+	Type    string              `json:"type"`
+	Label   map[string][]string `json:"label"`
+	Items   []iiifCanvas        `json:"items"`
+}
+
+type iiifCanvas struct {
+	ID     string               `json:"id"`
+	Type   string               `json:"type"`
+	Height int                  `json:"height"`
+	Width  int                  `json:"width"`
+	Items  []iiifAnnotationPage `json:"items"`
+}
+
+type iiifAnnotationPage struct {
+	ID    string           `json:"id"`
+	Type  string           `json:"type"`
+	Items []iiifAnnotation `json:"items"`
+}
+
+type iiifAnnotation struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Motivation string      `json:"motivation"`
+	Body       iiifImgBody `json:"body"`
+	Target     string      `json:"target"`
+}
+
+type iiifImgBody struct {
+	ID      string           `json:"id"`
+	Type    string           `json:"type"`
+	Format  string           `json:"format"`
+	Height  int              `json:"height"`
+	Width   int              `json:"width"`
+	Service []iiifImgService `json:"service"`
+}
+
+type iiifImgService struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Profile string `json:"profile"`
+}
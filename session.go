@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/sync/singleflight"
+)
+
+// SessionManager wraps a publicsuffix-aware cookiejar.Jar for one upstream
+// (JSTOR or ARTSTOR) and keeps it fresh. It coalesces concurrent re-auth
+// attempts into a single login via singleflight, and preemptively renews the
+// session on a timer so the next request doesn't have to pay for a login
+// round trip
+type SessionManager struct {
+	name      string
+	ttl       time.Duration
+	loginFunc func(jar *cookiejar.Jar) error
+
+	jar *cookiejar.Jar
+
+	group singleflight.Group
+}
+
+// newSessionManager creates a SessionManager for the named upstream. loginFunc
+// performs the actual login request using the given jar, so the resulting
+// session cookies land directly in it
+func newSessionManager(name string, ttl time.Duration, loginFunc func(jar *cookiejar.Jar) error) *SessionManager {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return &SessionManager{name: name, ttl: ttl, loginFunc: loginFunc, jar: jar}
+}
+
+// Cookies returns the session cookies applicable to u. cookiejar.Jar is safe
+// for concurrent use, so no locking is needed here
+func (sm *SessionManager) Cookies(u *url.URL) []*http.Cookie {
+	return sm.jar.Cookies(u)
+}
+
+// Login establishes the initial session. It is not coalesced; call once at
+// startup before the background refresh loop begins
+func (sm *SessionManager) Login() error {
+	return sm.loginFunc(sm.jar)
+}
+
+// ReAuth renews the session. Concurrent callers (for example a burst of
+// requests that all hit a 401/403 at once) are coalesced into a single login
+func (sm *SessionManager) ReAuth() error {
+	_, err, _ := sm.group.Do("login", func() (interface{}, error) {
+		jstorSessionReauthTotal.WithLabelValues(sm.name).Inc()
+		return nil, sm.loginFunc(sm.jar)
+	})
+	return err
+}
+
+// StartRefreshLoop launches a goroutine that preemptively re-authenticates
+// every ttl interval so the session stays warm instead of only renewing in
+// response to a failed request
+func (sm *SessionManager) StartRefreshLoop() {
+	if sm.ttl <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(sm.ttl)
+		defer ticker.Stop()
+		for range ticker.C {
+			log.Info().Str("upstream", sm.name).Msg("Pre-emptively renewing session")
+			if err := sm.ReAuth(); err != nil {
+				log.Error().Str("upstream", sm.name).Err(err).Msg("Unable to renew session")
+			}
+		}
+	}()
+}